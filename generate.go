@@ -1,7 +1,6 @@
 package structtograph
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -9,82 +8,132 @@ import (
 	"os/exec"
 	"reflect"
 	"strings"
+	"text/template"
 )
 
 type Dot interface {
 	AddStruct(i interface{}, flatten []string, opts ...Opts) error
+	AddGraph(root interface{}, opts ...Opts) error
 	Connect(i1 interface{}, n1 string, i2 interface{}, n2 string, label ...string) error
 	Output(w io.Writer) error
 	OutputPng(fn string) error
+	OutputSVG(fn string) error
+	OutputDotString() string
+	SetRenderer(r Renderer)
 }
 
 type Opts struct {
-	Rank     int
-	NoFields bool
+	Rank        int
+	NoFields    bool
+	FieldFilter FieldFilter
+	ShowNil     bool
+
+	// RowTemplate, when set, is a text/template evaluated against a
+	// TemplateField for every rendered field, overriding its display name.
+	// The default ("{{.Name}}") reproduces today's plain field name.
+	RowTemplate string
+
+	// HeaderTemplate, when set, is a text/template evaluated against a
+	// TemplateHeader, overriding a node's heading. The default
+	// ("{{typeName .Type}}") reproduces today's plain type name.
+	HeaderTemplate string
 }
 
 func Rank(r int) Opts {
 	return Opts{Rank: r}
 }
 
+// FieldFilter reports whether a struct field should be rendered in the
+// generated record label, analogous to go/ast.FieldFilter. It is consulted
+// for every field, including fields of flattened sub-structs. value is the
+// field's actual runtime value whenever AddStruct (called directly or via
+// AddGraph) resolves i to a concrete struct; it falls back to the zero
+// reflect.Value for the field's type only when i itself can't be resolved
+// to one, e.g. a nil pointer, an empty slice/array, or a bare type value,
+// or, for a flattened sub-struct's fields, when the field holding that
+// sub-struct is a nil pointer or an empty slice/array.
+type FieldFilter func(name string, value reflect.Value) bool
+
+// NotNilFilter is a FieldFilter that mirrors go/ast.NotNilFilter: it hides
+// fields whose Chan, Func, Interface, Map, Ptr or Slice value is nil.
+func NotNilFilter(_ string, value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return !value.IsNil()
+	}
+	return true
+}
+
 var ErrNotStruct = errors.New("not a struct type")
 
 var maxdepth = 5 // maximum level of nesting flattened structs
 
 type dot struct {
-	directed bool
-	structs  *bytes.Buffer
-	conns    *bytes.Buffer
+	directed      bool
+	nodes         []Node
+	edges         []Edge
+	renderer      Renderer
+	defaultFilter FieldFilter
+	nilSeq        int
 }
 
 func NewDot(directed bool) Dot {
-	return &dot{directed: directed, structs: new(bytes.Buffer), conns: new(bytes.Buffer)}
+	return &dot{directed: directed, renderer: DotRenderer{}}
+}
+
+// NewDotWithFilter behaves like NewDot, but applies filter to every
+// AddStruct call that does not set its own Opts.FieldFilter.
+func NewDotWithFilter(directed bool, filter FieldFilter) Dot {
+	return &dot{directed: directed, renderer: DotRenderer{}, defaultFilter: filter}
+}
+
+// NewDotWithRenderer behaves like NewDot, but serializes Output through r
+// instead of the default DotRenderer, e.g. MermaidRenderer or
+// PlantUMLRenderer.
+func NewDotWithRenderer(directed bool, r Renderer) Dot {
+	return &dot{directed: directed, renderer: r}
+}
+
+// SetRenderer switches the backend Output serializes through.
+func (d *dot) SetRenderer(r Renderer) {
+	d.renderer = r
 }
 
 func (d *dot) Output(w io.Writer) error {
-	if d.directed {
-		fmt.Fprintf(w, "digraph ")
-	} else {
-		fmt.Fprintf(w, "graph ")
-	}
-	fmt.Fprintf(w, ` recordmapping {
-	rankdir = "LR";
-	nodesep=0.9;
-	//compound=true;
-	newrank=true;
-	ranksep=0.9;
-
-	fontname="Open Sans"
-	node [fontname="Open Sans"]
-	edge [fontname="Open Sans"]		
-	node [fontsize = "16"];
-	edge [fontsize = "12"];
-
-`)
-	_, err := w.Write(d.structs.Bytes())
-	if err != nil {
-		return err
-	}
-	fmt.Fprintln(w)
-	_, err = w.Write(d.conns.Bytes())
-	fmt.Fprintf(w, "\n}\n")
+	_, err := io.WriteString(w, d.renderer.Render(d.nodes, d.edges, d.directed))
 	return err
 }
 
+// OutputDotString renders the accumulated graph as Graphviz dot source,
+// regardless of the active Renderer, for embedding directly in a web UI.
+func (d *dot) OutputDotString() string {
+	return DotRenderer{}.Render(d.nodes, d.edges, d.directed)
+}
+
 func (d *dot) OutputPng(fn string) error {
-	fn = strings.TrimSuffix(fn, ".png")
+	return d.outputViaDot(fn, "png")
+}
+
+// OutputSVG renders the graph to an SVG file by invoking `dot -Tsvg`, the
+// same way OutputPng renders a PNG.
+func (d *dot) OutputSVG(fn string) error {
+	return d.outputViaDot(fn, "svg")
+}
+
+func (d *dot) outputViaDot(fn string, format string) error {
+	fn = strings.TrimSuffix(fn, "."+format)
 
 	out, err := os.Create(fn + ".dot")
 	if err != nil {
 		return fmt.Errorf("error creating dot file: %w", err)
 	}
-	err = d.Output(out)
+	_, err = out.WriteString(d.OutputDotString())
 	if err != nil {
 		return fmt.Errorf("error writing dot file: %w", err)
 	}
 	out.Close()
 
-	cmd := exec.Command("dot", "-Tpng", "-o"+fn+".png", fn+".dot")
+	cmd := exec.Command("dot", "-T"+format, "-o"+fn+"."+format, fn+".dot")
 	cmd.Stderr, cmd.Stdout = os.Stderr, os.Stdout
 	err = cmd.Run()
 	if err != nil {
@@ -94,43 +143,107 @@ func (d *dot) OutputPng(fn string) error {
 }
 
 func (d *dot) AddStruct(i interface{}, flatten []string, opts ...Opts) error {
-	if v := reflect.ValueOf(i); v.Kind() == reflect.Pointer || v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
-		i = v.Elem()
+	it, iv := derefValue(reflect.ValueOf(i))
+	if it == nil {
+		return ErrNotStruct
 	}
-	it := reflect.TypeOf(i)
 
-	if strings.HasPrefix(reflect.ValueOf(i).String(), "reflect.") {
-		return nil
+	if !iv.IsValid() || iv.Kind() != reflect.Struct {
+		iv = reflect.Zero(it)
+	}
+
+	filter := d.defaultFilter
+	if len(opts) == 1 && opts[0].FieldFilter != nil {
+		filter = opts[0].FieldFilter
 	}
 
 	if it.Kind() == reflect.Struct {
-		fmt.Fprintf(d.structs, "subgraph \"cluster_%s\" {\n", it)
-		fmt.Fprintf(d.structs, "  label = < <B>%s</B> >\n", it)
-		fmt.Fprintf(d.structs, "  color = transparent\n")
+		n := Node{ID: it.String(), Heading: it.String()}
+		if len(opts) == 1 {
+			n.Rank, n.RankSet = opts[0].Rank, true
+		} else if r, ok := tagRank(it); ok {
+			n.Rank, n.RankSet = r, true
+		}
+
+		var rowTmpl *template.Template
 		if len(opts) == 1 {
-			fmt.Fprintf(d.structs, "  rank = %d\n", opts[0].Rank)
+			rowTmpl = parseTemplate("row", opts[0].RowTemplate)
+			if headerTmpl := parseTemplate("header", opts[0].HeaderTemplate); headerTmpl != nil {
+				if heading, ok := execTemplate(headerTmpl, TemplateHeader{Type: it, Kind: it.Kind(), Value: iv}); ok {
+					n.Heading = heading
+				}
+			}
 		}
-		fmt.Fprintln(d.structs)
-		fmt.Fprintf(d.structs, "\"%s\" [\n", it)
+
 		if len(opts) == 1 && opts[0].NoFields {
-			fmt.Fprintf(d.structs, "  label = \"%v\" \n", d.summaryStruct(it))
+			n.IsSummary = true
+			n.Summary = it.NumField()
 		} else {
-			fmt.Fprintf(d.structs, "  label = \"%v\" \n", d.labelStruct(it, []string{}, flatten))
+			n.Fields = buildFields(it, iv, []string{}, flatten, filter, rowTmpl)
 		}
-		fmt.Fprintf(d.structs, "  shape = \"record\"\n")
-		fmt.Fprintf(d.structs, "]\n}\n")
+
+		d.nodes = append(d.nodes, n)
+		d.connectTaggedEdges(it)
 	}
 
 	return ErrNotStruct
 }
 
-func (d *dot) summaryStruct(it reflect.Type) string {
-	return fmt.Sprintf("<fields> %d ...", it.NumField())
+// tagRank returns the first rank=N found in a graph struct tag among it's
+// top-level fields, for callers that don't pass an explicit Opts.
+func tagRank(it reflect.Type) (int, bool) {
+	for i := 0; i < it.NumField(); i++ {
+		if tag := parseGraphTag(it.Field(i).Tag); tag.HasRank {
+			return tag.Rank, true
+		}
+	}
+	return 0, false
 }
 
-func (d *dot) labelStruct(it reflect.Type, depth []string, flatten []string) string {
+// connectTaggedEdges emits a Connect for every top-level field of it tagged
+// graph:"edge=OtherField.TargetPort", so callers don't need a manual
+// Connect call to link that field to another struct's node. OtherField
+// names another field of it; its (dereferenced) type is connected to from
+// the tagged field's port to TargetPort.
+func (d *dot) connectTaggedEdges(it reflect.Type) {
+	for i := 0; i < it.NumField(); i++ {
+		field := it.Field(i)
+		tag := parseGraphTag(field.Tag)
+		if tag.Edge == "" {
+			continue
+		}
+
+		parts := strings.SplitN(tag.Edge, ".", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		otherField, ok := it.FieldByName(parts[0])
+		if !ok {
+			continue
+		}
+		ot := otherField.Type
+		if ot.Kind() == reflect.Pointer || ot.Kind() == reflect.Slice || ot.Kind() == reflect.Array {
+			ot = ot.Elem()
+		}
+		if ot.Kind() != reflect.Struct {
+			continue
+		}
+
+		port := tag.Port
+		if port == "" {
+			port = field.Name
+		}
+		d.Connect(reflect.Zero(it).Interface(), port, reflect.Zero(ot).Interface(), parts[1])
+	}
+}
+
+// buildFields walks it's fields, guided by iv's concrete values when
+// available, into the neutral Field IR every Renderer consumes, applying
+// FieldFilter and graph struct tags along the way. rowTmpl, if non-nil,
+// overrides each field's display name via Opts.RowTemplate.
+func buildFields(it reflect.Type, iv reflect.Value, depth []string, flatten []string, filter FieldFilter, rowTmpl *template.Template) []Field {
 	if len(depth) > maxdepth {
-		return ""
+		return nil
 	}
 
 	flattenMap := make(map[string]bool, len(flatten))
@@ -138,30 +251,263 @@ func (d *dot) labelStruct(it reflect.Type, depth []string, flatten []string) str
 		flattenMap[s] = true
 	}
 
-	s := ""
+	var fields []Field
 	for i := 0; i < it.NumField(); i++ {
 		field := it.Field(i)
 		if strings.HasPrefix(field.Name, "XXX_") {
 			continue
 		}
+
+		tag := parseGraphTag(field.Tag)
+		if tag.Skip {
+			continue
+		}
+
+		fv := reflect.Zero(field.Type)
+		if iv.IsValid() && iv.Kind() == reflect.Struct {
+			fv = iv.Field(i)
+		}
+		if filter != nil && !filter(field.Name, fv) {
+			continue
+		}
+
+		name := field.Name
+		if tag.Label != "" {
+			name = tag.Label
+		}
+		port := tag.Port
+		if port == "" {
+			port = strings.Join(append(depth, field.Name), "_")
+		}
+		if rowTmpl != nil {
+			ctx := TemplateField{
+				Name:   name,
+				Type:   field.Type,
+				Kind:   field.Type.Kind(),
+				Tag:    field.Tag,
+				Depth:  len(depth),
+				PortID: port,
+				Value:  fv,
+			}
+			if rendered, ok := execTemplate(rowTmpl, ctx); ok {
+				name = rendered
+			}
+		}
+
 		ft := field.Type
+		fsv := fv
 		if ft.Kind() == reflect.Pointer || ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array {
 			ft = ft.Elem()
-		}
-		if ft.Kind() == reflect.Struct {
-			if _, ok := flattenMap[field.Name]; ok {
-				flat := d.labelStruct(ft, append(depth, field.Name), flatten)
-				if flat != "" {
-					flat = "|" + flat
+			switch fsv.Kind() {
+			case reflect.Pointer:
+				if fsv.IsNil() {
+					fsv = reflect.Value{}
+				} else {
+					fsv = fsv.Elem()
+				}
+			case reflect.Slice, reflect.Array:
+				if fsv.Len() > 0 {
+					fsv = fsv.Index(0)
+				} else {
+					fsv = reflect.Value{}
 				}
-				s += fmt.Sprintf("{<%v> %v %v }|", strings.Join(append(depth, field.Name), "_"), field.Name, flat)
-				continue
 			}
 		}
-		s += fmt.Sprintf("<%v> %v|", strings.Join(append(depth, field.Name), "_"), field.Name)
+
+		if ft.Kind() == reflect.Struct && (flattenMap[field.Name] || tag.Flatten) {
+			fields = append(fields, Field{
+				Port:     port,
+				Name:     name,
+				Children: buildFields(ft, fsv, append(depth, field.Name), flatten, filter, rowTmpl),
+			})
+			continue
+		}
+
+		fields = append(fields, Field{Port: port, Name: name, Color: tag.Color})
 	}
 
-	return strings.TrimSuffix(s, "|")
+	return fields
+}
+
+// AddGraph walks root, a concrete value such as a pointer to a struct, and
+// recursively follows its pointers, slices, arrays and maps to discover the
+// live object graph rooted at it. It calls AddStruct once per distinct
+// struct type it finds and Connect for every pointer/slice/map traversal,
+// labelling each edge with the field path that produced it (e.g.
+// "Foo.Bar[3].Baz"). Visited pointer addresses are tracked in an internal
+// map[uintptr]bool so reference cycles terminate instead of recursing
+// forever; a revisited pointer still gets a Connect edge to the
+// already-known node, so shared substructure renders as a shared node
+// rather than being silently dropped. Nil pointers are suppressed unless
+// opts set ShowNil.
+func (d *dot) AddGraph(root interface{}, opts ...Opts) error {
+	var o Opts
+	if len(opts) == 1 {
+		o = opts[0]
+	}
+
+	visited := make(map[uintptr]bool)
+	seen := make(map[reflect.Type]bool)
+
+	v := reflect.ValueOf(root)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ErrNotStruct
+		}
+		if v.Kind() == reflect.Pointer {
+			visited[v.Pointer()] = true
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ErrNotStruct
+	}
+
+	return d.walkStruct(v, "", visited, seen, o)
+}
+
+// walkStruct walks owner's reference fields; prefix is the field path that
+// led to owner, prepended to each edge label ("" for the root).
+func (d *dot) walkStruct(owner reflect.Value, prefix string, visited map[uintptr]bool, seen map[reflect.Type]bool, o Opts) error {
+	ot := owner.Type()
+	if !seen[ot] {
+		seen[ot] = true
+		so := Opts{FieldFilter: o.FieldFilter, RowTemplate: o.RowTemplate, HeaderTemplate: o.HeaderTemplate}
+		if err := d.AddStruct(structValue(owner), nil, so); err != nil && err != ErrNotStruct {
+			return err
+		}
+	}
+
+	for i := 0; i < ot.NumField(); i++ {
+		field := ot.Field(i)
+		if strings.HasPrefix(field.Name, "XXX_") || !isRefKind(field.Type.Kind()) {
+			continue
+		}
+		path := field.Name
+		if prefix != "" {
+			path = prefix + "." + field.Name
+		}
+		if err := d.walkRef(owner, field.Name, owner.Field(i), path, visited, seen, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkRef follows a single pointer/slice/array/map/interface field down to
+// whatever struct(s) it ultimately references, emitting a Connect edge
+// (labelled with path) and recursing into each one via walkStruct.
+func (d *dot) walkRef(owner reflect.Value, port string, fv reflect.Value, path string, visited map[uintptr]bool, seen map[reflect.Type]bool, o Opts) error {
+	switch fv.Kind() {
+	case reflect.Interface:
+		if fv.IsNil() {
+			return nil
+		}
+		return d.walkRef(owner, port, fv.Elem(), path, visited, seen, o)
+
+	case reflect.Pointer:
+		if fv.IsNil() {
+			if o.ShowNil {
+				d.addNilNode(owner, port, path)
+			}
+			return nil
+		}
+		addr := fv.Pointer()
+		target := fv.Elem()
+		if visited[addr] {
+			// Already visited: still draw an edge to the existing node
+			// for this shared/cyclic reference, just don't re-walk it.
+			if target.Kind() != reflect.Struct {
+				return nil
+			}
+			return d.Connect(structValue(owner), port, structValue(target), "", path)
+		}
+		visited[addr] = true
+		return d.walkRef(owner, port, target, path, visited, seen, o)
+
+	case reflect.Slice, reflect.Array:
+		for idx := 0; idx < fv.Len(); idx++ {
+			if err := d.walkRef(owner, port, fv.Index(idx), fmt.Sprintf("%s[%d]", path, idx), visited, seen, o); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		iter := fv.MapRange()
+		for iter.Next() {
+			p := fmt.Sprintf("%s[%v]", path, iter.Key().Interface())
+			if err := d.walkRef(owner, port, iter.Value(), p, visited, seen, o); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Struct:
+		if err := d.Connect(structValue(owner), port, structValue(fv), "", path); err != nil {
+			return err
+		}
+		return d.walkStruct(fv, path, visited, seen, o)
+	}
+
+	return nil
+}
+
+func (d *dot) addNilNode(owner reflect.Value, port string, path string) {
+	d.nilSeq++
+	id := fmt.Sprintf("nil_%d", d.nilSeq)
+	d.nodes = append(d.nodes, Node{ID: id, Point: true})
+	d.edges = append(d.edges, Edge{
+		FromID:   reflect.TypeOf(structValue(owner)).String(),
+		FromPort: port,
+		ToID:     id,
+		Label:    path,
+	})
+}
+
+// isRefKind reports whether k is a kind AddGraph follows looking for
+// further struct references: pointers, slices, arrays, maps and interfaces.
+func isRefKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map, reflect.Interface:
+		return true
+	}
+	return false
+}
+
+// structValue returns v's value as an interface{}, falling back to the
+// type's zero value when v was obtained from an unexported field and can't
+// be interfaced directly.
+func structValue(v reflect.Value) interface{} {
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return reflect.Zero(v.Type()).Interface()
+}
+
+// derefValue unwraps v through any chain of pointers/slices/arrays down to
+// the type AddStruct actually renders, returning that type alongside the
+// concrete value it points/indexes to. It returns an invalid iv (rather
+// than panicking) for a nil pointer or an empty slice/array, so AddStruct
+// can fall back to its documented type-only rendering; it returns a nil
+// Type if v itself is invalid (e.g. a literal nil interface{}).
+func derefValue(v reflect.Value) (reflect.Type, reflect.Value) {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Slice || v.Kind() == reflect.Array) {
+		switch {
+		case v.Kind() == reflect.Pointer && v.IsNil():
+			return v.Type().Elem(), reflect.Value{}
+		case v.Kind() != reflect.Pointer && v.Len() == 0:
+			return v.Type().Elem(), reflect.Value{}
+		case v.Kind() == reflect.Pointer:
+			v = v.Elem()
+		default:
+			v = v.Index(0)
+		}
+	}
+	if !v.IsValid() {
+		return nil, reflect.Value{}
+	}
+	return v.Type(), v
 }
 
 func (d *dot) Connect(i1 interface{}, n1 string, i2 interface{}, n2 string, label ...string) error {
@@ -174,23 +520,17 @@ func (d *dot) Connect(i1 interface{}, n1 string, i2 interface{}, n2 string, labe
 	it1 := reflect.TypeOf(i1)
 	it2 := reflect.TypeOf(i2)
 
-	c1 := `"` + it1.String() + `"`
-	c2 := `"` + it2.String() + `"`
+	e := Edge{FromID: it1.String(), ToID: it2.String()}
 	if it1.Kind() == reflect.Struct && n1 != "" {
-		c1 += ":" + n1
+		e.FromPort = n1
 	}
 	if it2.Kind() == reflect.Struct && n2 != "" {
-		c2 += ":" + n2
+		e.ToPort = n2
 	}
-
-	connector := "--"
-	if d.directed {
-		connector = "->"
-	}
-
 	if len(label) == 1 {
-		c2 += fmt.Sprintf(" [ label = \"%s\" ]", label[0])
+		e.Label = label[0]
 	}
-	fmt.Fprintf(d.conns, "%s %s %s;\n", c1, connector, c2)
+
+	d.edges = append(d.edges, e)
 	return nil
 }
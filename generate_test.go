@@ -0,0 +1,116 @@
+package structtograph
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type ptrFoo struct {
+	Name string
+	Next *ptrFoo
+}
+
+func TestAddStructAcceptsPointerValue(t *testing.T) {
+	d := NewDot(true)
+	err := d.AddStruct(&ptrFoo{Name: "a"}, nil, Opts{FieldFilter: NotNilFilter})
+	if err != ErrNotStruct {
+		t.Fatalf("AddStruct error = %v, want ErrNotStruct", err)
+	}
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+
+	if strings.Contains(out, "reflect.Value") {
+		t.Errorf("AddStruct rendered the boxed reflect.Value instead of ptrFoo:\n%s", out)
+	}
+	if !strings.Contains(out, "structtograph.ptrFoo") {
+		t.Errorf("expected ptrFoo node, got:\n%s", out)
+	}
+	if strings.Contains(out, "<Next> Next") {
+		t.Errorf("NotNilFilter should have hidden the nil Next field, got:\n%s", out)
+	}
+}
+
+func TestAddStructFallsBackToTypeOnlyForNilPointer(t *testing.T) {
+	d := NewDot(true)
+	err := d.AddStruct((*ptrFoo)(nil), nil)
+	if err != ErrNotStruct {
+		t.Fatalf("AddStruct error = %v, want ErrNotStruct", err)
+	}
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+
+	if !strings.Contains(out, "structtograph.ptrFoo") || strings.Contains(out, "reflect.Value") {
+		t.Errorf("expected type-only ptrFoo node, got:\n%s", out)
+	}
+}
+
+func TestAddStructAcceptsSliceValueWithoutPanicking(t *testing.T) {
+	d := NewDot(true)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("AddStruct panicked on a slice value: %v", r)
+		}
+	}()
+
+	err := d.AddStruct([]ptrFoo{{Name: "a"}}, nil)
+	if err != ErrNotStruct {
+		t.Fatalf("AddStruct error = %v, want ErrNotStruct", err)
+	}
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	if !strings.Contains(buf.String(), "structtograph.ptrFoo") {
+		t.Errorf("expected ptrFoo node, got:\n%s", buf.String())
+	}
+}
+
+func TestNewDotWithFilterAppliesDefaultFilter(t *testing.T) {
+	d := NewDotWithFilter(true, NotNilFilter)
+	_ = d.AddStruct(ptrFoo{Name: "a"}, nil)
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+
+	if strings.Contains(out, "<Next> Next") {
+		t.Errorf("default filter from NewDotWithFilter should have hidden the nil Next field, got:\n%s", out)
+	}
+}
+
+func TestNewDotWithFilterOverriddenByPerCallFilter(t *testing.T) {
+	allowAll := func(_ string, _ reflect.Value) bool { return true }
+
+	d := NewDotWithFilter(true, NotNilFilter)
+	_ = d.AddStruct(ptrFoo{Name: "a"}, nil, Opts{FieldFilter: allowAll})
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+
+	if !strings.Contains(out, "<Next> Next") {
+		t.Errorf("per-call Opts.FieldFilter should override the graph-level default filter, got:\n%s", out)
+	}
+}
+
+func TestDerefValueUnwrapsPointerSliceAndArray(t *testing.T) {
+	foo := ptrFoo{Name: "a"}
+
+	if it, iv := derefValue(reflect.ValueOf(&foo)); it != reflect.TypeOf(foo) || iv.Interface().(ptrFoo).Name != "a" {
+		t.Errorf("derefValue(pointer) = %v, %v", it, iv)
+	}
+	if it, iv := derefValue(reflect.ValueOf((*ptrFoo)(nil))); it != reflect.TypeOf(foo) || iv.IsValid() {
+		t.Errorf("derefValue(nil pointer) = %v, %v, want valid type and invalid value", it, iv)
+	}
+	if it, iv := derefValue(reflect.ValueOf([]ptrFoo{foo})); it != reflect.TypeOf(foo) || iv.Interface().(ptrFoo).Name != "a" {
+		t.Errorf("derefValue(slice) = %v, %v", it, iv)
+	}
+	if it, iv := derefValue(reflect.ValueOf([]ptrFoo{})); it != reflect.TypeOf(foo) || iv.IsValid() {
+		t.Errorf("derefValue(empty slice) = %v, %v, want valid type and invalid value", it, iv)
+	}
+}
@@ -0,0 +1,101 @@
+package structtograph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type shared struct {
+	Value string
+}
+
+type holder struct {
+	A *shared
+	B *shared
+}
+
+func TestAddGraphConnectsBothReferencesToASharedPointer(t *testing.T) {
+	s := &shared{Value: "x"}
+	h := &holder{A: s, B: s}
+
+	d := NewDot(true)
+	if err := d.AddGraph(h); err != nil {
+		t.Fatalf("AddGraph: %v", err)
+	}
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+
+	if !strings.Contains(out, `:A -> `) || !strings.Contains(out, "structtograph.shared") {
+		t.Fatalf("expected an edge from A to shared, got:\n%s", out)
+	}
+	if !strings.Contains(out, `:B -> `) {
+		t.Errorf("revisiting a shared pointer through B should still emit an edge instead of being dropped, got:\n%s", out)
+	}
+}
+
+type cyclic struct {
+	Name string
+	Self *cyclic
+}
+
+func TestAddGraphTerminatesOnSelfCycle(t *testing.T) {
+	c := &cyclic{Name: "root"}
+	c.Self = c
+
+	d := NewDot(true)
+	if err := d.AddGraph(c); err != nil {
+		t.Fatalf("AddGraph: %v", err)
+	}
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+	if !strings.Contains(out, `:Self -> "structtograph.cyclic"`) {
+		t.Errorf("expected a self-edge for the cyclic reference, got:\n%s", out)
+	}
+}
+
+type nilHolder struct {
+	Name string
+	Next *nilHolder
+}
+
+func TestAddGraphShowNilEmitsPointMarker(t *testing.T) {
+	h := &nilHolder{Name: "root"}
+
+	d := NewDot(true)
+	if err := d.AddGraph(h, Opts{ShowNil: true}); err != nil {
+		t.Fatalf("AddGraph: %v", err)
+	}
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+
+	if !strings.Contains(out, `shape = "point"`) {
+		t.Errorf("expected a nil point marker, got:\n%s", out)
+	}
+	if !strings.Contains(out, `:Next -> "nil_`) {
+		t.Errorf("expected an edge from Next to the nil marker, got:\n%s", out)
+	}
+}
+
+func TestAddGraphWithoutShowNilOmitsNilMarker(t *testing.T) {
+	h := &nilHolder{Name: "root"}
+
+	d := NewDot(true)
+	if err := d.AddGraph(h); err != nil {
+		t.Fatalf("AddGraph: %v", err)
+	}
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+
+	if strings.Contains(out, `shape = "point"`) {
+		t.Errorf("nil marker should not render without ShowNil, got:\n%s", out)
+	}
+}
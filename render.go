@@ -0,0 +1,299 @@
+package structtograph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Field is one row of a rendered struct node: a name shown to the reader
+// and the port other edges connect to. Children holds the rows of a
+// flattened sub-struct, rendered nested under this row instead of getting
+// their own node.
+type Field struct {
+	Port     string
+	Name     string
+	Color    string
+	Children []Field
+}
+
+// Node is one struct type to render as a box/record/class, built by
+// AddStruct from a Go struct type (and, via AddGraph, its values).
+type Node struct {
+	// ID uniquely identifies the node, e.g. a reflect.Type.String(). Edge
+	// FromID/ToID reference nodes by this same string.
+	ID      string
+	Heading string
+	Fields  []Field
+
+	// IsSummary renders Summary (a field count) instead of Fields, for
+	// Opts.NoFields.
+	IsSummary bool
+	Summary   int
+
+	Rank    int
+	RankSet bool
+
+	// Point marks a synthetic placeholder node, such as the small "nil"
+	// marker AddGraph emits for a nil pointer under Opts.ShowNil.
+	Point bool
+}
+
+// Edge is a connection between two nodes (or, for AddGraph's ShowNil
+// markers, a node and a synthetic Point node).
+type Edge struct {
+	FromID   string
+	FromPort string
+	ToID     string
+	ToPort   string
+	Label    string
+}
+
+// Renderer serializes the nodes and edges accumulated by AddStruct,
+// AddGraph and Connect into a backend's textual graph format. DotRenderer,
+// MermaidRenderer and PlantUMLRenderer are the built-in implementations;
+// Output dispatches to whichever one is active.
+type Renderer interface {
+	Render(nodes []Node, edges []Edge, directed bool) string
+}
+
+// DotRenderer renders the graph as Graphviz dot source using HTML-like
+// record/table labels. It is the default Renderer and the one OutputPng,
+// OutputSVG and OutputDotString always use, since those feed the `dot`
+// command line tool.
+type DotRenderer struct{}
+
+func (DotRenderer) Render(nodes []Node, edges []Edge, directed bool) string {
+	var b strings.Builder
+
+	if directed {
+		b.WriteString("digraph ")
+	} else {
+		b.WriteString("graph ")
+	}
+	b.WriteString(` recordmapping {
+	rankdir = "LR";
+	nodesep=0.9;
+	//compound=true;
+	newrank=true;
+	ranksep=0.9;
+
+	fontname="Open Sans"
+	node [fontname="Open Sans"]
+	edge [fontname="Open Sans"]
+	node [fontsize = "16"];
+	edge [fontsize = "12"];
+
+`)
+
+	for _, n := range nodes {
+		b.WriteString(dotNode(n))
+	}
+
+	b.WriteString("\n")
+	for _, e := range edges {
+		b.WriteString(dotEdge(e, directed))
+	}
+	b.WriteString("\n}\n")
+
+	return b.String()
+}
+
+func dotNode(n Node) string {
+	if n.Point {
+		return fmt.Sprintf("\"%s\" [ shape = \"point\", label = \"nil\" ];\n", n.ID)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "subgraph \"cluster_%s\" {\n", n.ID)
+	fmt.Fprintf(&b, "  label = < <B>%s</B> >\n", n.Heading)
+	fmt.Fprintf(&b, "  color = transparent\n")
+	if n.RankSet {
+		fmt.Fprintf(&b, "  rank = %d\n", n.Rank)
+	}
+	fmt.Fprintln(&b)
+	fmt.Fprintf(&b, "\"%s\" [\n", n.ID)
+	switch {
+	case n.IsSummary:
+		fmt.Fprintf(&b, "  label = \"<fields> %d ...\" \n", n.Summary)
+	case hasFieldColor(n.Fields):
+		fmt.Fprintf(&b, "  label = < <TABLE BORDER=\"0\" CELLBORDER=\"1\" CELLSPACING=\"0\">%s</TABLE> > \n", dotHTMLFields(n.Fields))
+	default:
+		fmt.Fprintf(&b, "  label = \"%v\" \n", dotRecordFields(n.Fields))
+	}
+	fmt.Fprintf(&b, "  shape = \"record\"\n")
+	fmt.Fprintf(&b, "]\n}\n")
+
+	return b.String()
+}
+
+func dotRecordFields(fields []Field) string {
+	s := ""
+	for _, f := range fields {
+		if len(f.Children) > 0 {
+			flat := dotRecordFields(f.Children)
+			if flat != "" {
+				flat = "|" + flat
+			}
+			s += fmt.Sprintf("{<%v> %v %v }|", f.Port, f.Name, flat)
+			continue
+		}
+		s += fmt.Sprintf("<%v> %v|", f.Port, f.Name)
+	}
+	return strings.TrimSuffix(s, "|")
+}
+
+func dotHTMLFields(fields []Field) string {
+	s := ""
+	for _, f := range fields {
+		if len(f.Children) > 0 {
+			s += fmt.Sprintf(`<TR><TD PORT="%s">%s</TD><TD><TABLE BORDER="0" CELLBORDER="1" CELLSPACING="0">%s</TABLE></TD></TR>`, f.Port, f.Name, dotHTMLFields(f.Children))
+			continue
+		}
+		cell := f.Name
+		if f.Color != "" {
+			cell = fmt.Sprintf(`<FONT COLOR="%s">%s</FONT>`, f.Color, f.Name)
+		}
+		s += fmt.Sprintf(`<TR><TD PORT="%s">%s</TD></TR>`, f.Port, cell)
+	}
+	return s
+}
+
+func hasFieldColor(fields []Field) bool {
+	for _, f := range fields {
+		if f.Color != "" || hasFieldColor(f.Children) {
+			return true
+		}
+	}
+	return false
+}
+
+func dotEdge(e Edge, directed bool) string {
+	c1 := `"` + e.FromID + `"`
+	if e.FromPort != "" {
+		c1 += ":" + e.FromPort
+	}
+	c2 := `"` + e.ToID + `"`
+	if e.ToPort != "" {
+		c2 += ":" + e.ToPort
+	}
+
+	connector := "--"
+	if directed {
+		connector = "->"
+	}
+
+	if e.Label != "" {
+		c2 += fmt.Sprintf(" [ label = \"%s\" ]", e.Label)
+	}
+
+	return fmt.Sprintf("%s %s %s;\n", c1, connector, c2)
+}
+
+// MermaidRenderer renders the graph as a Mermaid classDiagram block, with
+// one class per Node and an association per Edge.
+type MermaidRenderer struct{}
+
+func (MermaidRenderer) Render(nodes []Node, edges []Edge, directed bool) string {
+	var b strings.Builder
+
+	b.WriteString("classDiagram\n")
+	for _, n := range nodes {
+		if n.Point {
+			continue
+		}
+		fmt.Fprintf(&b, "class %s {\n", sanitizeID(n.ID))
+		if n.IsSummary {
+			fmt.Fprintf(&b, "  +... %d fields\n", n.Summary)
+		} else {
+			for _, name := range fieldNames(n.Fields, "") {
+				fmt.Fprintf(&b, "  +%s\n", name)
+			}
+		}
+		b.WriteString("}\n")
+	}
+
+	for _, e := range edges {
+		if e.ToID == "" {
+			continue
+		}
+		line := fmt.Sprintf("%s --> %s", sanitizeID(e.FromID), sanitizeID(e.ToID))
+		if e.Label != "" {
+			line += " : " + e.Label
+		}
+		b.WriteString(line + "\n")
+	}
+
+	return b.String()
+}
+
+// PlantUMLRenderer renders the graph as a PlantUML class diagram, with one
+// class per Node and an arrow per Edge.
+type PlantUMLRenderer struct{}
+
+func (PlantUMLRenderer) Render(nodes []Node, edges []Edge, directed bool) string {
+	var b strings.Builder
+
+	b.WriteString("@startuml\n")
+	for _, n := range nodes {
+		if n.Point {
+			continue
+		}
+		fmt.Fprintf(&b, "class %s {\n", sanitizeID(n.ID))
+		if n.IsSummary {
+			fmt.Fprintf(&b, "  .. %d fields ..\n", n.Summary)
+		} else {
+			for _, name := range fieldNames(n.Fields, "") {
+				fmt.Fprintf(&b, "  %s\n", name)
+			}
+		}
+		b.WriteString("}\n")
+	}
+
+	for _, e := range edges {
+		if e.ToID == "" {
+			continue
+		}
+		line := fmt.Sprintf("%s --> %s", sanitizeID(e.FromID), sanitizeID(e.ToID))
+		if e.Label != "" {
+			line += " : " + e.Label
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("@enduml\n")
+
+	return b.String()
+}
+
+// fieldNames flattens fields (and their flattened children, dotted under
+// their parent's name) into a list of display names, for the class-diagram
+// backends which have no notion of a nested record row.
+func fieldNames(fields []Field, prefix string) []string {
+	var names []string
+	for _, f := range fields {
+		name := f.Name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if len(f.Children) > 0 {
+			names = append(names, fieldNames(f.Children, name)...)
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// idSanitizer removes characters Mermaid/PlantUML identifiers can't contain
+// from a Go type's reflect.String() form, e.g. "[]*pkg.Foo": dots and
+// spaces become underscores, brackets and asterisks are dropped.
+var idSanitizer = strings.NewReplacer(
+	".", "_",
+	"[", "",
+	"]", "",
+	"*", "",
+	" ", "_",
+)
+
+func sanitizeID(id string) string {
+	return idSanitizer.Replace(id)
+}
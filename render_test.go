@@ -0,0 +1,84 @@
+package structtograph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type renderPerson struct {
+	Name string
+	Age  int
+}
+
+func TestOutputDotStringIgnoresActiveRenderer(t *testing.T) {
+	d := NewDot(true)
+	_ = d.AddStruct(renderPerson{Name: "a", Age: 1}, nil)
+	d.SetRenderer(MermaidRenderer{})
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	if strings.Contains(buf.String(), "digraph") {
+		t.Errorf("Output should use the active Mermaid renderer, got dot syntax:\n%s", buf.String())
+	}
+
+	dotOut := d.OutputDotString()
+	if !strings.Contains(dotOut, "digraph") || !strings.Contains(dotOut, "<Name> Name") {
+		t.Errorf("OutputDotString should always render dot syntax, got:\n%s", dotOut)
+	}
+}
+
+func TestMermaidRendererEmitsClassAndAssociation(t *testing.T) {
+	d := NewDotWithRenderer(true, MermaidRenderer{})
+	_ = d.AddStruct(renderPerson{}, nil)
+	_ = d.Connect(renderPerson{}, "Name", renderPerson{}, "", "self")
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "classDiagram\n") {
+		t.Errorf("expected classDiagram header, got:\n%s", out)
+	}
+	if !strings.Contains(out, "class structtograph_renderPerson {") {
+		t.Errorf("expected sanitized class name, got:\n%s", out)
+	}
+	if !strings.Contains(out, "+Name") || !strings.Contains(out, "+Age") {
+		t.Errorf("expected field rows, got:\n%s", out)
+	}
+	if !strings.Contains(out, "structtograph_renderPerson --> structtograph_renderPerson : self\n") {
+		t.Errorf("expected labelled association, got:\n%s", out)
+	}
+}
+
+func TestPlantUMLRendererEmitsClassAndArrow(t *testing.T) {
+	d := NewDotWithRenderer(false, PlantUMLRenderer{})
+	_ = d.AddStruct(renderPerson{}, nil)
+	_ = d.Connect(renderPerson{}, "Name", renderPerson{}, "", "self")
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "@startuml\n") || !strings.HasSuffix(out, "@enduml\n") {
+		t.Errorf("expected @startuml/@enduml wrapper, got:\n%s", out)
+	}
+	if !strings.Contains(out, "class structtograph_renderPerson {") {
+		t.Errorf("expected sanitized class name, got:\n%s", out)
+	}
+	if !strings.Contains(out, "structtograph_renderPerson --> structtograph_renderPerson : self\n") {
+		t.Errorf("expected labelled arrow, got:\n%s", out)
+	}
+}
+
+func TestFieldNamesFlattensChildren(t *testing.T) {
+	fields := []Field{
+		{Name: "Name"},
+		{Name: "Addr", Children: []Field{{Name: "City"}}},
+	}
+	got := fieldNames(fields, "")
+	want := []string{"Name", "Addr.City"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("fieldNames = %v, want %v", got, want)
+	}
+}
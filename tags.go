@@ -0,0 +1,81 @@
+package structtograph
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const graphTagKey = "graph"
+
+// graphTag holds the parsed contents of a `graph:"..."` struct tag, which
+// customizes how AddStruct (through buildFields, tagRank and
+// connectTaggedEdges) renders and links a field. Recognized keys: skip, label=Name, color=blue, port=custom_id,
+// flatten, rank=N and edge=OtherField.TargetPort. Keys are comma-separated,
+// following the convention of stdlib tags such as `json:"name,omitempty"`.
+type graphTag struct {
+	Skip    bool
+	Label   string
+	Color   string
+	Port    string
+	Flatten bool
+	Rank    int
+	HasRank bool
+	Edge    string
+}
+
+// parseGraphTag parses the `graph:"..."` tag on a struct field. A field
+// with no such tag, or an empty one, yields the zero graphTag, which
+// changes nothing about how the field is rendered.
+func parseGraphTag(tag reflect.StructTag) graphTag {
+	var gt graphTag
+
+	raw, ok := tag.Lookup(graphTagKey)
+	if !ok || raw == "" {
+		return gt
+	}
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key, val, hasVal := part, "", false
+		if idx := strings.Index(part, "="); idx >= 0 {
+			key, val, hasVal = part[:idx], part[idx+1:], true
+		}
+
+		switch key {
+		case "skip":
+			gt.Skip = true
+		case "flatten":
+			gt.Flatten = true
+		case "label":
+			if hasVal {
+				gt.Label = val
+			}
+		case "color":
+			if hasVal {
+				gt.Color = val
+			}
+		case "port":
+			if hasVal {
+				gt.Port = val
+			}
+		case "rank":
+			if hasVal {
+				if r, err := strconv.Atoi(val); err == nil {
+					gt.Rank = r
+					gt.HasRank = true
+				}
+			}
+		case "edge":
+			if hasVal {
+				gt.Edge = val
+			}
+		}
+	}
+
+	return gt
+}
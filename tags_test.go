@@ -0,0 +1,185 @@
+package structtograph
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGraphTag(t *testing.T) {
+	cases := []struct {
+		name string
+		tag  string
+		want graphTag
+	}{
+		{"empty", "", graphTag{}},
+		{"skip", "skip", graphTag{Skip: true}},
+		{"label", "label=Full Name", graphTag{Label: "Full Name"}},
+		{"color", "color=blue", graphTag{Color: "blue"}},
+		{"port", "port=custom_id", graphTag{Port: "custom_id"}},
+		{"flatten", "flatten", graphTag{Flatten: true}},
+		{"rank", "rank=2", graphTag{Rank: 2, HasRank: true}},
+		{"edge", "edge=Other.Field", graphTag{Edge: "Other.Field"}},
+		{"combined", "flatten,color=red,port=p1", graphTag{Flatten: true, Color: "red", Port: "p1"}},
+		{"bad rank ignored", "rank=notanumber", graphTag{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			st := reflect.StructTag(`graph:"` + c.tag + `"`)
+			got := parseGraphTag(st)
+			if got != c.want {
+				t.Errorf("parseGraphTag(%q) = %+v, want %+v", c.tag, got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseGraphTagAbsent(t *testing.T) {
+	got := parseGraphTag(reflect.StructTag(`json:"name"`))
+	if got != (graphTag{}) {
+		t.Errorf("parseGraphTag with no graph tag = %+v, want zero value", got)
+	}
+}
+
+type taggedAddress struct {
+	City string
+}
+
+type taggedPerson struct {
+	Name    string        `graph:"label=Full Name"`
+	private string        `graph:"skip"` //lint:ignore U1000 exercised via reflection
+	Age     int           `graph:"color=blue"`
+	Addr    taggedAddress `graph:"flatten"`
+}
+
+func TestAddStructHonorsSkipLabelAndFlattenTags(t *testing.T) {
+	d := NewDot(true)
+	if err := d.AddStruct(taggedPerson{Name: "a", Age: 30}, nil); err != ErrNotStruct {
+		t.Fatalf("AddStruct error = %v, want ErrNotStruct", err)
+	}
+
+	var buf bytes.Buffer
+	if err := d.Output(&buf); err != nil {
+		t.Fatalf("Output: %v", err)
+	}
+	out := buf.String()
+
+	if strings.Contains(out, "private") {
+		t.Errorf("skipped field rendered:\n%s", out)
+	}
+	if !strings.Contains(out, "Full Name") {
+		t.Errorf("label override missing:\n%s", out)
+	}
+	if !strings.Contains(out, `PORT="Addr_City"`) || !strings.Contains(out, "City") {
+		t.Errorf("flattened sub-field missing:\n%s", out)
+	}
+}
+
+func TestAddStructSwitchesToHTMLLabelForColorTag(t *testing.T) {
+	d := NewDot(true)
+	_ = d.AddStruct(taggedPerson{}, nil)
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+
+	if !strings.Contains(out, `<FONT COLOR="blue">Age</FONT>`) {
+		t.Errorf("colored field not rendered as HTML:\n%s", out)
+	}
+	if !strings.Contains(out, "label = <") {
+		t.Errorf("expected HTML-mode label, got:\n%s", out)
+	}
+}
+
+type edgeA struct {
+	Other  *edgeB `graph:"edge=Target.Name"`
+	Target edgeB
+}
+
+type edgeB struct {
+	Name string
+}
+
+func TestAddStructEmitsTaggedEdge(t *testing.T) {
+	d := NewDot(true)
+	_ = d.AddStruct(edgeA{}, nil)
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+
+	if !strings.Contains(out, `"edgeA":Other -> "edgeB":Name;`) && !strings.Contains(out, `"structtograph.edgeA":Other -> "structtograph.edgeB":Name;`) {
+		t.Errorf("expected tagged edge connection, got:\n%s", out)
+	}
+}
+
+type embInner struct {
+	Value string `graph:"color=red"`
+}
+
+type embFlatten struct {
+	embInner `graph:"flatten"`
+	Name     string
+}
+
+type embPlain struct {
+	embInner
+	Name string
+}
+
+type embSkip struct {
+	embInner `graph:"skip"`
+	Name     string
+}
+
+func TestAddStructHandlesEmbeddedFields(t *testing.T) {
+	t.Run("flatten promotes the embedded field and honors its sub-field tags", func(t *testing.T) {
+		d := NewDot(true)
+		_ = d.AddStruct(embFlatten{}, nil)
+
+		var buf bytes.Buffer
+		_ = d.Output(&buf)
+		out := buf.String()
+
+		if !strings.Contains(out, `PORT="embInner_Value"`) {
+			t.Errorf("flattened embedded field missing its port:\n%s", out)
+		}
+		if !strings.Contains(out, `<FONT COLOR="red">Value</FONT>`) {
+			t.Errorf("color tag on the embedded sub-field not honored:\n%s", out)
+		}
+	})
+
+	t.Run("without flatten the embedded field renders as its own unexpanded row", func(t *testing.T) {
+		d := NewDot(true)
+		_ = d.AddStruct(embPlain{}, nil)
+
+		var buf bytes.Buffer
+		_ = d.Output(&buf)
+		out := buf.String()
+
+		if !strings.Contains(out, "<embInner> embInner") {
+			t.Errorf("expected an unexpanded embInner row, got:\n%s", out)
+		}
+		if strings.Contains(out, "Value") {
+			t.Errorf("embedded sub-field should not be promoted without flatten:\n%s", out)
+		}
+	})
+
+	t.Run("skip on the embedded field hides it entirely", func(t *testing.T) {
+		d := NewDot(true)
+		_ = d.AddStruct(embSkip{}, nil)
+
+		var buf bytes.Buffer
+		_ = d.Output(&buf)
+		out := buf.String()
+
+		if strings.Contains(out, "embInner") || strings.Contains(out, "Value") {
+			t.Errorf("skip-tagged embedded field should be fully hidden:\n%s", out)
+		}
+		if !strings.Contains(out, "Name") {
+			t.Errorf("sibling field should still render:\n%s", out)
+		}
+	})
+}
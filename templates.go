@@ -0,0 +1,87 @@
+package structtograph
+
+import (
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// TemplateField is the context exposed to Opts.RowTemplate while rendering
+// one field's row. Value is the field's actual runtime value whenever
+// AddStruct (called directly or via AddGraph) resolves its input to a
+// concrete struct; it falls back to the zero reflect.Value for the field's
+// type only when that input can't be resolved to one, e.g. a nil pointer,
+// an empty slice/array, or a bare type value, or, for a flattened
+// sub-struct's fields, when the field holding that sub-struct is a nil
+// pointer or an empty slice/array.
+type TemplateField struct {
+	Name   string
+	Type   reflect.Type
+	Kind   reflect.Kind
+	Tag    reflect.StructTag
+	Depth  int
+	PortID string
+	Value  reflect.Value
+}
+
+// TemplateHeader is the context exposed to Opts.HeaderTemplate while
+// rendering a node's heading.
+type TemplateHeader struct {
+	Type  reflect.Type
+	Kind  reflect.Kind
+	Value reflect.Value
+}
+
+// templateFuncs are the helpers available to RowTemplate/HeaderTemplate, in
+// the spirit of tfortools' reflection-aware template helpers.
+var templateFuncs = template.FuncMap{
+	"deref":     derefType,
+	"typeName":  func(t reflect.Type) string { return t.String() },
+	"shortType": func(t reflect.Type) string { return t.Name() },
+	"hasTag": func(tag reflect.StructTag, key string) bool {
+		_, ok := tag.Lookup(key)
+		return ok
+	},
+	"fieldByName": func(v reflect.Value, name string) reflect.Value {
+		if !v.IsValid() || v.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+		return v.FieldByName(name)
+	},
+}
+
+// derefType unwraps any chain of pointer/slice/array types down to the
+// innermost element type, e.g. []*pkg.Foo to pkg.Foo.
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t
+}
+
+// parseTemplate compiles a RowTemplate/HeaderTemplate. It returns nil
+// rather than an error for an empty or invalid template, so callers just
+// fall back to the library's default rendering.
+func parseTemplate(name, raw string) *template.Template {
+	if raw == "" {
+		return nil
+	}
+	t, err := template.New(name).Funcs(templateFuncs).Parse(raw)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// execTemplate runs t against data, reporting false (rather than an error)
+// if t is nil or execution fails.
+func execTemplate(t *template.Template, data interface{}) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", false
+	}
+	return b.String(), true
+}
@@ -0,0 +1,78 @@
+package structtograph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type templatePerson struct {
+	Name string `proto:"1"`
+	Age  int
+}
+
+func TestAddStructDefaultTemplatesMatchPlainOutput(t *testing.T) {
+	withTemplates := NewDot(true)
+	_ = withTemplates.AddStruct(templatePerson{}, nil, Opts{RowTemplate: "{{.Name}}", HeaderTemplate: "{{typeName .Type}}"})
+
+	plain := NewDot(true)
+	_ = plain.AddStruct(templatePerson{}, nil, Opts{})
+
+	var bufT, bufP bytes.Buffer
+	_ = withTemplates.Output(&bufT)
+	_ = plain.Output(&bufP)
+
+	if bufT.String() != bufP.String() {
+		t.Errorf("default-equivalent templates changed output:\ngot:\n%s\nwant:\n%s", bufT.String(), bufP.String())
+	}
+}
+
+func TestAddStructRowTemplateReadsStructTag(t *testing.T) {
+	d := NewDot(true)
+	err := d.AddStruct(templatePerson{}, nil, Opts{
+		RowTemplate: `{{.Name}}{{if hasTag .Tag "proto"}} (#{{.Tag.Get "proto"}}){{end}}`,
+	})
+	if err != ErrNotStruct {
+		t.Fatalf("AddStruct error = %v, want ErrNotStruct", err)
+	}
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+
+	if !strings.Contains(out, "Name (#1)") {
+		t.Errorf("expected tag-driven row label, got:\n%s", out)
+	}
+	if strings.Contains(out, "Age (#") {
+		t.Errorf("untagged field should not render a tag suffix, got:\n%s", out)
+	}
+}
+
+func TestAddStructHeaderTemplateUsesShortType(t *testing.T) {
+	d := NewDot(true)
+	_ = d.AddStruct(templatePerson{}, nil, Opts{HeaderTemplate: "{{shortType .Type}}"})
+
+	var buf bytes.Buffer
+	_ = d.Output(&buf)
+	out := buf.String()
+
+	if !strings.Contains(out, "<B>templatePerson</B>") {
+		t.Errorf("expected short-type heading, got:\n%s", out)
+	}
+}
+
+func TestParseTemplateInvalidFallsBackToNil(t *testing.T) {
+	if tmpl := parseTemplate("bad", "{{.Unclosed"); tmpl != nil {
+		t.Errorf("parseTemplate with invalid syntax = %v, want nil", tmpl)
+	}
+	if tmpl := parseTemplate("empty", ""); tmpl != nil {
+		t.Errorf("parseTemplate with empty string = %v, want nil", tmpl)
+	}
+}
+
+func TestExecTemplateFailureReportsFalse(t *testing.T) {
+	tmpl := parseTemplate("missingkey", "{{.NoSuchField}}")
+	if _, ok := execTemplate(tmpl, TemplateHeader{}); ok {
+		t.Errorf("execTemplate with a field absent from data should report false")
+	}
+}